@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"math/rand/v2"
 	"os"
+	"os/signal"
 	"runtime"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -23,54 +25,158 @@ type Result struct {
 	Seed       uint64  `json:"seed"`
 	DurationMS int64   `json:"duration_ms"`
 	RSSBytes   uint64  `json:"rss_bytes"`
-}
+	Shards     int     `json:"shards,omitempty"`
+	MapImpl    string  `json:"map_impl"`
+	Hasher     string  `json:"hasher,omitempty"`
+
+	P50NS  int64 `json:"p50_ns"`
+	P90NS  int64 `json:"p90_ns"`
+	P99NS  int64 `json:"p99_ns"`
+	P999NS int64 `json:"p999_ns"`
+	MaxNS  int64 `json:"max_ns"`
+
+	ReadLatency  HistStats `json:"read_latency"`
+	WriteLatency HistStats `json:"write_latency"`
+
+	Distribution string  `json:"distribution"`
+	ZipfS        float64 `json:"zipf_s,omitempty"`
+	HotOpsPct    float64 `json:"hot_ops_pct,omitempty"`
+	HotKeysPct   float64 `json:"hot_keys_pct,omitempty"`
 
-func rss() uint64 {
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-	return m.Sys // close to RSS; for external RSS, parse /proc/self/statm on Linux
+	Mix         string               `json:"mix,omitempty"`
+	OpCounts    map[string]uint64    `json:"op_counts,omitempty"`
+	OpLatencyNS map[string]HistStats `json:"op_latency_ns,omitempty"`
+
+	RSSPeakBytes    uint64 `json:"rss_peak_bytes"`
+	RSSMeanBytes    uint64 `json:"rss_mean_bytes"`
+	GCCount         uint32 `json:"gc_count"`
+	GCPauseP99NS    int64  `json:"gc_pause_p99_ns"`
+	AllocBytesTotal uint64 `json:"alloc_bytes_total"`
 }
 
+// hdrDump, when set via --hdr-dump, makes runWork emit a second JSON
+// line with the full histogram bucket counts for offline plotting.
+var hdrDump bool
+
+// distribution and its parameters are resolved once in main into a
+// shared KeyGen and recorded here so runWork can stamp them onto the
+// Result without threading them through every model function.
+var (
+	distribution = "uniform"
+	zipfS        float64
+	hotOpsPct    float64
+	hotKeysPct   float64
+)
+
+// opMix, when set via --mix, switches workers from the default
+// get/put-only dispatch to a weighted choice over get/put/delete/
+// scan/getOrCompute. scanLimit bounds how many keys a scan op visits.
+var (
+	opMix     *OpMix
+	scanLimit int
+)
+
+// durationMode, when true, makes the worker loops run until ctx is
+// canceled instead of for a fixed iteration count: either --duration
+// has elapsed, or (with --serve and no --duration) the process
+// received an interrupt. The final JSON summary is still printed once
+// the run ends; --serve additionally exposes live counters throughout.
+var durationMode bool
+
 func main() {
 	threads := flag.Int("threads", 8, "")
 	iters := flag.Int("iterations", 2_000_000, "")
 	keys := flag.Int("keys", 100_000, "")
 	readRatio := flag.Float64("read-ratio", 0.9, "")
 	seed := flag.Uint64("seed", 42, "")
-	model := flag.String("model", "syncmap", "syncmap|sharded")
+	model := flag.String("model", "syncmap", "syncmap|sharded|xsync|shardedmutex")
 	shardsN := flag.Int("shards", 64, "for sharded model")
+	hasherName := flag.String("hasher", "mod", "mod|fnv|xxhash|maphash, key-to-shard hash for the sharded and shardedmutex models")
+	stripesN := flag.Int("stripes", 64, "number of lock stripes, for shardedmutex model")
+	flag.BoolVar(&hdrDump, "hdr-dump", false, "also print a second JSON line with full histogram bucket counts")
+	flag.StringVar(&distribution, "distribution", "uniform", "uniform|zipf|latest|hotset")
+	flag.Float64Var(&zipfS, "zipf-s", 0.99, "zipfian skew, for zipf|latest distributions")
+	flag.Float64Var(&hotOpsPct, "hot-ops", 0.9, "fraction of ops that hit the hot keyset, for hotset distribution")
+	flag.Float64Var(&hotKeysPct, "hot-keys", 0.1, "fraction of the keyspace that is hot, for hotset distribution")
+	mixFlag := flag.String("mix", "", "op mix, e.g. \"get=80,put=15,delete=4,scan=1\" (default: get/put driven by --read-ratio)")
+	flag.IntVar(&scanLimit, "scan-limit", 100, "max keys visited by one scan op")
+	serveAddr := flag.String("serve", "", "if set, expose live Prometheus metrics on this address (e.g. :9090) for the duration of the run")
+	duration := flag.Duration("duration", 0, "if set, run for this long instead of a fixed --iterations count")
 	flag.Parse()
 
 	debug.SetGCPercent(100)
 	runtime.GOMAXPROCS(runtime.NumCPU())
 
+	if *mixFlag != "" {
+		m, err := parseMix(*mixFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		opMix = m
+	}
+
+	ctx := context.Background()
+	cancel := func() {}
+	switch {
+	case *duration > 0:
+		durationMode = true
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+	case *serveAddr != "":
+		durationMode = true
+		ctx, cancel = signal.NotifyContext(ctx, os.Interrupt)
+	}
+	defer cancel()
+
+	if *serveAddr != "" {
+		live = newLiveState(*model)
+		go serveMetrics(*serveAddr)
+	}
+
+	kg := newKeyGen(distribution, *keys, zipfS, hotOpsPct, hotKeysPct)
+
 	switch *model {
 	case "syncmap":
-		runSyncMap(*threads, *iters, *keys, *readRatio, *seed)
+		runSyncMap(ctx, *threads, *iters, *keys, *readRatio, *seed, kg)
 	case "sharded":
-		runSharded(*threads, *iters, *keys, *readRatio, *seed, *shardsN)
+		runSharded(ctx, *threads, *iters, *keys, *readRatio, *seed, *shardsN, kg, newHasher(*hasherName))
+	case "xsync", "cachemap":
+		runXsync(ctx, *threads, *iters, *keys, *readRatio, *seed, kg)
+	case "shardedmutex":
+		runShardedMutex(ctx, *threads, *iters, *keys, *readRatio, *seed, *stripesN, kg, newHasher(*hasherName))
 	default:
 		fmt.Fprintln(os.Stderr, "unknown model")
 		os.Exit(1)
 	}
 }
 
-func runSyncMap(threads, iterations, keys int, readRatio float64, seed uint64) {
+func runSyncMap(ctx context.Context, threads, iterations, keys int, readRatio float64, seed uint64, kg KeyGen) {
 	var m sync.Map
 	// prefill
 	for i := 0; i < keys; i++ {
 		m.Store(i, int64(0))
 	}
-	runWork("go"+runtime.Version(), "threads-sync.Map", threads, iterations, keys, readRatio, seed, func(ctx context.Context, tid int, n int) {
+	runWork(ctx, "go"+runtime.Version(), "threads-sync.Map", threads, iterations, keys, readRatio, seed, 0, "sync.Map", "", func(ctx context.Context, tid int, n int, h *localHist, oc *opCounters) {
 		r := rand.New(rand.NewPCG(seed+uint64(tid), seed*1315423911+uint64(tid)))
 		reads := int(readRatio * 1000.0)
-		for i := 0; i < n; i++ {
-			k := int(r.IntN(keys))
-			if int(r.IntN(1000)) < reads {
+		for i := 0; n < 0 || i < n; i++ {
+			if n < 0 && i&1023 == 0 && ctx.Err() != nil {
+				break
+			}
+			k := kg.Next(r)
+			op := OpGet
+			if opMix != nil {
+				op = opMix.pick(r)
+			} else if int(r.IntN(1000)) >= reads {
+				op = OpPut
+			}
+			opStart := time.Now()
+			switch op {
+			case OpGet:
 				if v, ok := m.Load(k); ok {
 					_ = v.(int64)
 				}
-			} else {
+			case OpPut:
 				for {
 					v, _ := m.Load(k)
 					old := int64(0)
@@ -86,78 +192,217 @@ func runSyncMap(threads, iterations, keys int, readRatio float64, seed uint64) {
 						m.LoadOrStore(k, int64(0))
 					}
 				}
+			case OpDelete:
+				m.Delete(k)
+			case OpScan:
+				visited := 0
+				m.Range(func(key, value any) bool {
+					visited++
+					return visited < scanLimit
+				})
+			case OpGetOrCompute:
+				m.LoadOrStore(k, int64(0))
 			}
+			recordOp(h, oc, op, time.Since(opStart).Nanoseconds())
 		}
 	}, &m)
 }
 
 type shard struct {
-	mu sync.Mutex
-	m  map[int]int64
+	mu  sync.Mutex
+	m   map[int]int64
+	ops atomic.Uint64
 }
 
-func runSharded(threads, iterations, keys int, readRatio float64, seed uint64, shardsN int) {
+func runSharded(ctx context.Context, threads, iterations, keys int, readRatio float64, seed uint64, shardsN int, kg KeyGen, hasher Hasher) {
 	shards := make([]*shard, shardsN)
 	for i := range shards {
 		shards[i] = &shard{m: make(map[int]int64, keys/shardsN+1)}
 	}
 	// prefill
 	for i := 0; i < keys; i++ {
-		s := shards[i%shardsN]
+		s := shards[hasher.Hash(i)%uint64(shardsN)]
 		s.mu.Lock()
 		s.m[i] = 0
 		s.mu.Unlock()
 	}
-	runWork("go"+runtime.Version(), "threads-sharded", threads, iterations, keys, readRatio, seed, func(ctx context.Context, tid int, n int) {
+	runWork(ctx, "go"+runtime.Version(), "threads-sharded", threads, iterations, keys, readRatio, seed, shardsN, "map[int]int64+mutex-per-shard", hasher.String(), func(ctx context.Context, tid int, n int, h *localHist, oc *opCounters) {
 		r := rand.New(rand.NewPCG(seed+uint64(tid), seed*1315423911+uint64(tid)))
 		reads := int(readRatio * 1000.0)
-		for i := 0; i < n; i++ {
-			k := int(r.IntN(keys))
-			s := shards[k%shardsN]
-			if int(r.IntN(1000)) < reads {
+		for i := 0; n < 0 || i < n; i++ {
+			if n < 0 && i&1023 == 0 && ctx.Err() != nil {
+				break
+			}
+			k := kg.Next(r)
+			s := shards[hasher.Hash(k)%uint64(shardsN)]
+			s.ops.Add(1)
+			op := OpGet
+			if opMix != nil {
+				op = opMix.pick(r)
+			} else if int(r.IntN(1000)) >= reads {
+				op = OpPut
+			}
+			opStart := time.Now()
+			switch op {
+			case OpGet:
 				s.mu.Lock()
 				_ = s.m[k]
 				s.mu.Unlock()
-			} else {
+			case OpPut:
 				s.mu.Lock()
 				s.m[k]++
 				s.mu.Unlock()
+			case OpDelete:
+				s.mu.Lock()
+				delete(s.m, k)
+				s.mu.Unlock()
+			case OpScan:
+				s.mu.Lock()
+				visited := 0
+				for range s.m {
+					visited++
+					if visited >= scanLimit {
+						break
+					}
+				}
+				s.mu.Unlock()
+			case OpGetOrCompute:
+				s.mu.Lock()
+				if _, ok := s.m[k]; !ok {
+					s.m[k] = 0
+				}
+				s.mu.Unlock()
 			}
+			recordOp(h, oc, op, time.Since(opStart).Nanoseconds())
 		}
 	}, shards)
+	sizes := make([]float64, len(shards))
+	ops := make([]float64, len(shards))
+	for i, s := range shards {
+		s.mu.Lock()
+		sizes[i] = float64(len(s.m))
+		s.mu.Unlock()
+		ops[i] = float64(s.ops.Load())
+	}
+	logShardDistribution(sizes, ops)
 }
 
-func runWork(rt, model string, threads, iterations, keys int, readRatio float64, seed uint64, worker func(context.Context, int, int), obj any) {
+func runWork(ctx context.Context, rt, model string, threads, iterations, keys int, readRatio float64, seed uint64, shards int, mapImpl, hasherName string, worker func(context.Context, int, int, *localHist, *opCounters), obj any) {
 	per := iterations / threads
-	ctx := context.Background()
+	if durationMode {
+		per = -1
+	}
+	locals := make([]*localHist, threads)
+	counters := make([]*opCounters, threads)
+
+	memCtx, stopMemSampler := context.WithCancel(ctx)
+	ring := newMemRing(memRingCapacity)
+	go runMemSampler(memCtx, ring)
+
 	start := time.Now()
 	var wg sync.WaitGroup
 	wg.Add(threads)
 	for t := 0; t < threads; t++ {
 		tid := t
+		locals[tid] = newLocalHist()
+		counters[tid] = &opCounters{}
 		go func() {
 			defer wg.Done()
-			worker(ctx, tid, per)
+			worker(ctx, tid, per, locals[tid], counters[tid])
 		}()
 	}
 	wg.Wait()
 	dur := time.Since(start).Milliseconds()
 	_ = obj // keep from optimizing away
 
+	// Only stamp the distribution parameters that actually govern the
+	// chosen distribution, so a plain uniform run doesn't emit
+	// "zipf_s":0.99 just because that's the flag default.
+	var distZipfS, distHotOpsPct, distHotKeysPct float64
+	switch distribution {
+	case "zipf", "latest":
+		distZipfS = zipfS
+	case "hotset":
+		distHotOpsPct = hotOpsPct
+		distHotKeysPct = hotKeysPct
+	}
+
+	hist := mergeHist(locals)
+	combined := hist.combined()
+	ops := mergeOpCounters(counters)
+	totalOps := per * threads
+	if durationMode {
+		totalOps = 0
+		for _, c := range ops {
+			totalOps += int(c)
+		}
+	}
+
 	// force GC to stabilize mem reading
 	runtime.GC()
 	time.Sleep(50 * time.Millisecond)
+	stopMemSampler()
+	mem := summarizeMem(ring)
 	res := Result{
-		Runtime:    rt,
-		Model:      model,
-		Threads:    threads,
-		Iterations: per * threads,
-		Keys:       keys,
-		ReadRatio:  readRatio,
-		Seed:       seed,
-		DurationMS: dur,
-		RSSBytes:   rss(),
+		Runtime:      rt,
+		Model:        model,
+		Threads:      threads,
+		Iterations:   totalOps,
+		Keys:         keys,
+		ReadRatio:    readRatio,
+		Seed:         seed,
+		DurationMS:   dur,
+		RSSBytes:     rss(),
+		Shards:       shards,
+		MapImpl:      mapImpl,
+		Hasher:       hasherName,
+		P50NS:        percentileNS(combined, 0.50),
+		P90NS:        percentileNS(combined, 0.90),
+		P99NS:        percentileNS(combined, 0.99),
+		P999NS:       percentileNS(combined, 0.999),
+		MaxNS:        maxLatencyNS(combined),
+		ReadLatency:  statsFor(hist.read()),
+		WriteLatency: statsFor(hist.write()),
+		Distribution: distribution,
+		ZipfS:        distZipfS,
+		HotOpsPct:    distHotOpsPct,
+		HotKeysPct:   distHotKeysPct,
+
+		RSSPeakBytes:    mem.RSSPeakBytes,
+		RSSMeanBytes:    mem.RSSMeanBytes,
+		GCCount:         mem.GCCount,
+		GCPauseP99NS:    mem.GCPauseP99NS,
+		AllocBytesTotal: mem.AllocBytesTotal,
+	}
+	if opMix != nil {
+		res.Mix = opMix.spec
+		res.OpCounts = make(map[string]uint64, numOpTypes)
+		res.OpLatencyNS = make(map[string]HistStats, numOpTypes)
+		for op := OpType(0); op < numOpTypes; op++ {
+			if ops[op] == 0 {
+				continue
+			}
+			res.OpCounts[op.String()] = ops[op]
+			res.OpLatencyNS[op.String()] = statsFor(hist.buckets[op])
+		}
 	}
 	b, _ := json.Marshal(res)
 	fmt.Println(string(b))
+
+	if hdrDump {
+		dump := HistDump{
+			MinNS:               histMinNS,
+			SubBucketsPerOctave: histSubBucketsPerOctave,
+			ReadBuckets:         hist.read(),
+			WriteBuckets:        hist.write(),
+		}
+		if opMix != nil {
+			dump.OpBuckets = make(map[string][]uint64, numOpTypes)
+			for op := OpType(0); op < numOpTypes; op++ {
+				dump.OpBuckets[op.String()] = hist.buckets[op]
+			}
+		}
+		db, _ := json.Marshal(dump)
+		fmt.Println(string(db))
+	}
 }
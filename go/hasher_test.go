@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestXXHashHasherMatchesReferenceVector(t *testing.T) {
+	// xxh64(seed=0) of the 8 little-endian bytes of the key 42,
+	// computed against a from-spec reference implementation.
+	const key = 42
+	const want = uint64(0xb556806fb6d14353)
+	if got := (xxhashHasher{}).Hash(key); got != want {
+		t.Fatalf("xxhashHasher.Hash(%d) = %#x, want %#x", key, got, want)
+	}
+}
+
+func TestHashersAreNamedAndDeterministic(t *testing.T) {
+	for _, name := range []string{"mod", "fnv", "xxhash", "maphash"} {
+		h := newHasher(name)
+		if got, want := h.String(), name; got != want {
+			t.Errorf("newHasher(%q).String() = %q, want %q", name, got, want)
+		}
+		a := h.Hash(7)
+		b := h.Hash(7)
+		if a != b {
+			t.Errorf("%s: Hash(7) not deterministic within one Hasher instance: %d != %d", name, a, b)
+		}
+	}
+}
+
+func TestDistStats(t *testing.T) {
+	vs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	min, max, mean, stddev := distStats(vs)
+	if min != 2 {
+		t.Errorf("min = %v, want 2", min)
+	}
+	if max != 9 {
+		t.Errorf("max = %v, want 9", max)
+	}
+	if mean != 5 {
+		t.Errorf("mean = %v, want 5", mean)
+	}
+	if stddev != 2 {
+		t.Errorf("stddev = %v, want 2", stddev)
+	}
+}
+
+func TestDistStatsEmpty(t *testing.T) {
+	min, max, mean, stddev := distStats(nil)
+	if min != 0 || max != 0 || mean != 0 || stddev != 0 {
+		t.Errorf("distStats(nil) = (%v, %v, %v, %v), want all zero", min, max, mean, stddev)
+	}
+}
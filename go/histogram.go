@@ -0,0 +1,153 @@
+package main
+
+import "math"
+
+// Latency buckets are log2-spaced between histMinNS and histMaxNS, with
+// histSubBucketsPerOctave sub-buckets per power-of-two octave. That
+// works out to roughly 20 buckets per decade, enough resolution to see
+// tail behavior without the bucket count blowing up.
+const (
+	histMinNS               = 100.0
+	histMaxNS               = 10e9
+	histSubBucketsPerOctave = 6
+)
+
+var histNumBuckets = int(math.Log2(histMaxNS/histMinNS)*histSubBucketsPerOctave) + 1
+
+func histBucket(ns int64) int {
+	f := float64(ns)
+	if f < histMinNS {
+		f = histMinNS
+	}
+	if f > histMaxNS {
+		f = histMaxNS
+	}
+	idx := int(math.Log2(f/histMinNS) * histSubBucketsPerOctave)
+	if idx >= histNumBuckets {
+		idx = histNumBuckets - 1
+	}
+	return idx
+}
+
+func histBucketUpperNS(i int) int64 {
+	return int64(histMinNS * math.Pow(2, float64(i+1)/histSubBucketsPerOctave))
+}
+
+// localHist is a per-goroutine latency recorder with one bucket slice
+// per OpType. It needs no synchronization while recording; runWork
+// merges every goroutine's localHist into one Histogram once all
+// goroutines have finished.
+type localHist struct {
+	buckets [numOpTypes][]uint64
+}
+
+func newLocalHist() *localHist {
+	h := &localHist{}
+	for i := range h.buckets {
+		h.buckets[i] = make([]uint64, histNumBuckets)
+	}
+	return h
+}
+
+func (h *localHist) record(op OpType, ns int64) { h.buckets[op][histBucket(ns)]++ }
+
+// Histogram is the merge of every goroutine's localHist for one run.
+type Histogram struct {
+	buckets [numOpTypes][]uint64
+}
+
+func mergeHist(locals []*localHist) *Histogram {
+	h := &Histogram{}
+	for i := range h.buckets {
+		h.buckets[i] = make([]uint64, histNumBuckets)
+	}
+	for _, l := range locals {
+		for op := range h.buckets {
+			for i, c := range l.buckets[op] {
+				h.buckets[op][i] += c
+			}
+		}
+	}
+	return h
+}
+
+func (h *Histogram) combined() []uint64 {
+	c := make([]uint64, histNumBuckets)
+	for op := range h.buckets {
+		for i, v := range h.buckets[op] {
+			c[i] += v
+		}
+	}
+	return c
+}
+
+func (h *Histogram) read() []uint64  { return h.buckets[OpGet] }
+func (h *Histogram) write() []uint64 { return h.buckets[OpPut] }
+
+func countOf(buckets []uint64) uint64 {
+	var total uint64
+	for _, c := range buckets {
+		total += c
+	}
+	return total
+}
+
+// HistStats is the percentile summary of one bucket slice.
+type HistStats struct {
+	P50  int64 `json:"p50_ns"`
+	P90  int64 `json:"p90_ns"`
+	P99  int64 `json:"p99_ns"`
+	P999 int64 `json:"p999_ns"`
+	Max  int64 `json:"max_ns"`
+}
+
+func statsFor(buckets []uint64) HistStats {
+	return HistStats{
+		P50:  percentileNS(buckets, 0.50),
+		P90:  percentileNS(buckets, 0.90),
+		P99:  percentileNS(buckets, 0.99),
+		P999: percentileNS(buckets, 0.999),
+		Max:  maxLatencyNS(buckets),
+	}
+}
+
+func percentileNS(buckets []uint64, p float64) int64 {
+	var total uint64
+	for _, c := range buckets {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(p * float64(total)))
+	if target == 0 {
+		target = 1
+	}
+	var cum uint64
+	for i, c := range buckets {
+		cum += c
+		if cum >= target {
+			return histBucketUpperNS(i)
+		}
+	}
+	return histBucketUpperNS(len(buckets) - 1)
+}
+
+func maxLatencyNS(buckets []uint64) int64 {
+	for i := len(buckets) - 1; i >= 0; i-- {
+		if buckets[i] > 0 {
+			return histBucketUpperNS(i)
+		}
+	}
+	return 0
+}
+
+// HistDump is the full bucket layout, emitted as a second JSON line
+// when --hdr-dump is set, for offline plotting.
+type HistDump struct {
+	MinNS               float64             `json:"min_ns"`
+	SubBucketsPerOctave int                 `json:"sub_buckets_per_octave"`
+	ReadBuckets         []uint64            `json:"read_buckets"`
+	WriteBuckets        []uint64            `json:"write_buckets"`
+	OpBuckets           map[string][]uint64 `json:"op_buckets,omitempty"`
+}
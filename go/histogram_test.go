@@ -0,0 +1,87 @@
+package main
+
+import "testing"
+
+func TestHistNumBuckets(t *testing.T) {
+	// 100ns..10s at 6 sub-buckets/octave is ~26.6 octaves, i.e. 160
+	// buckets. A silent off-by-one here shifts every bucket index.
+	const want = 160
+	if histNumBuckets != want {
+		t.Fatalf("histNumBuckets = %d, want %d", histNumBuckets, want)
+	}
+}
+
+func TestHistBucket(t *testing.T) {
+	cases := []struct {
+		name string
+		ns   int64
+		want int
+	}{
+		{"below min clamps to first bucket", 1, 0},
+		{"at min is first bucket", 100, 0},
+		{"just under an octave edge", 199, 5},
+		{"at the octave edge", 200, 6},
+		{"at max is last bucket", 10e9, histNumBuckets - 1},
+		{"above max clamps to last bucket", 20e9, histNumBuckets - 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := histBucket(c.ns); got != c.want {
+				t.Errorf("histBucket(%d) = %d, want %d", c.ns, got, c.want)
+			}
+		})
+	}
+}
+
+func TestPercentileNSUniformBucket(t *testing.T) {
+	// All samples land in one bucket: every percentile should resolve
+	// to that bucket's upper bound, regardless of which percentile.
+	buckets := make([]uint64, histNumBuckets)
+	const idx = 10
+	buckets[idx] = 5
+	want := histBucketUpperNS(idx)
+
+	for _, p := range []float64{0.50, 0.90, 0.99, 0.999} {
+		if got := percentileNS(buckets, p); got != want {
+			t.Errorf("percentileNS(p=%v) = %d, want %d", p, got, want)
+		}
+	}
+}
+
+func TestPercentileNSEmpty(t *testing.T) {
+	buckets := make([]uint64, histNumBuckets)
+	if got := percentileNS(buckets, 0.99); got != 0 {
+		t.Errorf("percentileNS on empty buckets = %d, want 0", got)
+	}
+}
+
+func TestPercentileNSSpansBuckets(t *testing.T) {
+	buckets := make([]uint64, histNumBuckets)
+	buckets[0] = 90
+	buckets[1] = 9
+	buckets[2] = 1
+	// p50 stays within the first (largest) bucket; p99 and p999 need
+	// the cumulative count from later buckets to reach their target.
+	if got, want := percentileNS(buckets, 0.50), histBucketUpperNS(0); got != want {
+		t.Errorf("p50 = %d, want %d", got, want)
+	}
+	if got, want := percentileNS(buckets, 0.99), histBucketUpperNS(1); got != want {
+		t.Errorf("p99 = %d, want %d", got, want)
+	}
+	if got, want := percentileNS(buckets, 0.999), histBucketUpperNS(2); got != want {
+		t.Errorf("p999 = %d, want %d", got, want)
+	}
+}
+
+func TestMaxLatencyNS(t *testing.T) {
+	buckets := make([]uint64, histNumBuckets)
+	if got := maxLatencyNS(buckets); got != 0 {
+		t.Errorf("maxLatencyNS on empty buckets = %d, want 0", got)
+	}
+	buckets[10] = 1
+	buckets[3] = 1
+	want := histBucketUpperNS(10)
+	if got := maxLatencyNS(buckets); got != want {
+		t.Errorf("maxLatencyNS = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	memSampleInterval = 100 * time.Millisecond
+	// memRingCapacity bounds how long a soak run can sample for before
+	// the ring starts overwriting its oldest entries: at one sample
+	// every 100ms, 36000 slots covers one hour.
+	memRingCapacity = 36000
+)
+
+// memSample is one tick of the background sampler.
+type memSample struct {
+	RSSBytes     uint64
+	HeapInuse    uint64
+	HeapObjects  uint64
+	NumGC        uint32
+	PauseTotalNS uint64
+}
+
+// memRing is a fixed-capacity circular buffer of memSamples. Once full
+// it overwrites the oldest entry, so a long soak run bounds memory
+// instead of growing a slice forever.
+type memRing struct {
+	mu      sync.Mutex
+	samples []memSample
+	next    int
+	full    bool
+}
+
+func newMemRing(capacity int) *memRing {
+	return &memRing{samples: make([]memSample, capacity)}
+}
+
+func (r *memRing) add(s memSample) {
+	r.mu.Lock()
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+	r.mu.Unlock()
+}
+
+func (r *memRing) all() []memSample {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]memSample(nil), r.samples[:r.next]...)
+	}
+	out := make([]memSample, 0, len(r.samples))
+	out = append(out, r.samples[r.next:]...)
+	out = append(out, r.samples[:r.next]...)
+	return out
+}
+
+// runMemSampler records RSS and GC/heap stats every memSampleInterval
+// until ctx is canceled. It's meant to run for the duration of one
+// runWork call, alongside the worker goroutines.
+func runMemSampler(ctx context.Context, ring *memRing) {
+	ticker := time.NewTicker(memSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var ms runtime.MemStats
+			runtime.ReadMemStats(&ms)
+			ring.add(memSample{
+				RSSBytes:     rss(),
+				HeapInuse:    ms.HeapInuse,
+				HeapObjects:  ms.HeapObjects,
+				NumGC:        ms.NumGC,
+				PauseTotalNS: ms.PauseTotalNs,
+			})
+		}
+	}
+}
+
+// memStats is the aggregate summary stamped onto Result.
+type memStats struct {
+	RSSPeakBytes    uint64
+	RSSMeanBytes    uint64
+	GCCount         uint32
+	GCPauseP99NS    int64
+	AllocBytesTotal uint64
+}
+
+func summarizeMem(ring *memRing) memStats {
+	samples := ring.all()
+	var stats memStats
+	if len(samples) > 0 {
+		var sum uint64
+		for _, s := range samples {
+			sum += s.RSSBytes
+			if s.RSSBytes > stats.RSSPeakBytes {
+				stats.RSSPeakBytes = s.RSSBytes
+			}
+		}
+		stats.RSSMeanBytes = sum / uint64(len(samples))
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	stats.AllocBytesTotal = ms.TotalAlloc
+	stats.GCCount = ms.NumGC
+
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	stats.GCPauseP99NS = percentileOf(gc.Pause, 0.99)
+
+	return stats
+}
+
+// percentileOf returns the p-th percentile of a []time.Duration,
+// treating it as an unordered sample (debug.GCStats.Pause is sorted
+// most-recent-first, not by magnitude).
+func percentileOf(pauses []time.Duration, p float64) int64 {
+	if len(pauses) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), pauses...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(float64(len(sorted))*p) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return int64(sorted[idx])
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func ringOf(samples []memSample) []uint64 {
+	out := make([]uint64, len(samples))
+	for i, s := range samples {
+		out[i] = s.RSSBytes
+	}
+	return out
+}
+
+func TestMemRingBeforeFull(t *testing.T) {
+	r := newMemRing(3)
+	r.add(memSample{RSSBytes: 1})
+	r.add(memSample{RSSBytes: 2})
+	got := ringOf(r.all())
+	if want := []uint64{1, 2}; !equalU64(got, want) {
+		t.Fatalf("all() = %v, want %v", got, want)
+	}
+}
+
+func TestMemRingWraps(t *testing.T) {
+	r := newMemRing(3)
+	for _, v := range []uint64{1, 2, 3, 4, 5} {
+		r.add(memSample{RSSBytes: v})
+	}
+	// Capacity 3: the oldest two entries (1, 2) should have been
+	// overwritten, leaving the last three in insertion order.
+	got := ringOf(r.all())
+	if want := []uint64{3, 4, 5}; !equalU64(got, want) {
+		t.Fatalf("all() after wrap = %v, want %v", got, want)
+	}
+}
+
+func equalU64(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPercentileOf(t *testing.T) {
+	pauses := []time.Duration{5, 1, 4, 2, 3}
+	cases := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0.50, 2},
+		{0.99, 4},
+		{1.0, 5},
+	}
+	for _, c := range cases {
+		if got := percentileOf(pauses, c.p); got != int64(c.want) {
+			t.Errorf("percentileOf(p=%v) = %d, want %d", c.p, got, c.want)
+		}
+	}
+}
+
+func TestPercentileOfEmpty(t *testing.T) {
+	if got := percentileOf(nil, 0.99); got != 0 {
+		t.Errorf("percentileOf(nil) = %d, want 0", got)
+	}
+}
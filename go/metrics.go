@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync/atomic"
+)
+
+// live holds the atomic counters read by the /metrics handler. It's
+// non-nil only when --serve is set, since the per-goroutine localHist/
+// opCounters used for the one-shot JSON summary aren't safe to read
+// from a concurrent HTTP handler goroutine.
+var live *liveState
+
+type liveState struct {
+	model          string
+	opTotal        [numOpTypes]atomic.Uint64
+	opBucketCounts [numOpTypes][]atomic.Uint64
+}
+
+func newLiveState(model string) *liveState {
+	ls := &liveState{model: model}
+	for i := range ls.opBucketCounts {
+		ls.opBucketCounts[i] = make([]atomic.Uint64, histNumBuckets)
+	}
+	return ls
+}
+
+func (ls *liveState) record(op OpType, ns int64) {
+	ls.opTotal[op].Add(1)
+	ls.opBucketCounts[op][histBucket(ns)].Add(1)
+}
+
+// recordOp updates both the per-goroutine histogram used for the
+// final JSON summary and, when running in --serve mode, the shared
+// live counters scraped by /metrics.
+func recordOp(h *localHist, oc *opCounters, op OpType, ns int64) {
+	h.record(op, ns)
+	oc[op]++
+	if live != nil {
+		live.record(op, ns)
+	}
+}
+
+// serveMetrics blocks serving Prometheus text-format metrics on addr
+// until the process exits.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", handleMetrics)
+	_ = http.ListenAndServe(addr, mux)
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP bench_ops_total Total operations performed, by model and op type.")
+	fmt.Fprintln(w, "# TYPE bench_ops_total counter")
+	if live != nil {
+		for op := OpType(0); op < numOpTypes; op++ {
+			fmt.Fprintf(w, "bench_ops_total{model=%q,op=%q} %d\n", live.model, op.String(), live.opTotal[op].Load())
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP bench_op_duration_seconds Per-op latency.")
+	fmt.Fprintln(w, "# TYPE bench_op_duration_seconds histogram")
+	if live != nil {
+		for op := OpType(0); op < numOpTypes; op++ {
+			writeCumulativeBuckets(w, "bench_op_duration_seconds", map[string]string{"model": live.model, "op": op.String()}, loadBucketCounts(live.opBucketCounts[op]))
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP bench_goroutines Live goroutine count.")
+	fmt.Fprintln(w, "# TYPE bench_goroutines gauge")
+	fmt.Fprintf(w, "bench_goroutines %d\n", runtime.NumGoroutine())
+
+	fmt.Fprintln(w, "# HELP bench_rss_bytes Resident set size.")
+	fmt.Fprintln(w, "# TYPE bench_rss_bytes gauge")
+	fmt.Fprintf(w, "bench_rss_bytes %d\n", rss())
+
+	fmt.Fprintln(w, "# HELP bench_gc_pause_seconds Recent GC stop-the-world pauses.")
+	fmt.Fprintln(w, "# TYPE bench_gc_pause_seconds histogram")
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	gcBuckets := make([]uint64, histNumBuckets)
+	for _, p := range gc.Pause {
+		gcBuckets[histBucket(p.Nanoseconds())]++
+	}
+	writeCumulativeBuckets(w, "bench_gc_pause_seconds", nil, gcBuckets)
+}
+
+func loadBucketCounts(buckets []atomic.Uint64) []uint64 {
+	out := make([]uint64, len(buckets))
+	for i := range buckets {
+		out[i] = buckets[i].Load()
+	}
+	return out
+}
+
+// writeCumulativeBuckets renders one Prometheus classic histogram:
+// cumulative "le" buckets derived from our log2-spaced latency
+// buckets, plus the trailing +Inf bucket and _sum/_count lines.
+func writeCumulativeBuckets(w http.ResponseWriter, name string, labels map[string]string, buckets []uint64) {
+	var cum, sumNS uint64
+	for i, c := range buckets {
+		cum += c
+		sumNS += c * uint64(histBucketUpperNS(i))
+		fmt.Fprintf(w, "%s_bucket{%sle=%q} %d\n", name, labelPairs(labels), secondsStr(histBucketUpperNS(i)), cum)
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", name, labelPairs(labels), cum)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, trimTrailingComma(labelPairs(labels)), secondsStr(int64(sumNS)))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, trimTrailingComma(labelPairs(labels)), cum)
+}
+
+func labelPairs(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := ""
+	for _, k := range keys {
+		s += fmt.Sprintf("%s=%q,", k, labels[k])
+	}
+	return s
+}
+
+func trimTrailingComma(s string) string {
+	if len(s) > 0 && s[len(s)-1] == ',' {
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+func secondsStr(ns int64) string {
+	return fmt.Sprintf("%g", float64(ns)/1e9)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLabelPairs(t *testing.T) {
+	if got := labelPairs(nil); got != "" {
+		t.Errorf("labelPairs(nil) = %q, want \"\"", got)
+	}
+	if got, want := labelPairs(map[string]string{"model": "sharded"}), `model="sharded",`; got != want {
+		t.Errorf("labelPairs(single) = %q, want %q", got, want)
+	}
+	// Keys must come out sorted regardless of map iteration order.
+	got := labelPairs(map[string]string{"op": "get", "model": "sharded"})
+	want := `model="sharded",op="get",`
+	if got != want {
+		t.Errorf("labelPairs(multi) = %q, want %q", got, want)
+	}
+}
+
+func TestTrimTrailingComma(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"", ""},
+		{"a,", "a"},
+		{"a,b,", "a,b"},
+		{"noComma", "noComma"},
+	}
+	for _, c := range cases {
+		if got := trimTrailingComma(c.in); got != c.want {
+			t.Errorf("trimTrailingComma(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestSecondsStr(t *testing.T) {
+	cases := []struct {
+		ns   int64
+		want string
+	}{
+		{0, "0"},
+		{1e9, "1"},
+		{1.5e9, "1.5"},
+		{100, "1e-07"},
+	}
+	for _, c := range cases {
+		if got := secondsStr(c.ns); got != c.want {
+			t.Errorf("secondsStr(%d) = %q, want %q", c.ns, got, c.want)
+		}
+	}
+}
+
+func TestWriteCumulativeBuckets(t *testing.T) {
+	w := httptest.NewRecorder()
+	buckets := []uint64{0, 2, 1}
+	writeCumulativeBuckets(w, "bench_test", map[string]string{"model": "x"}, buckets)
+	body := w.Body.String()
+
+	wantCumLine := fmt.Sprintf(`bench_test_bucket{model="x",le=%q} 3`, secondsStr(histBucketUpperNS(2)))
+	if !strings.Contains(body, wantCumLine) {
+		t.Errorf("body missing cumulative last bucket line %q, got:\n%s", wantCumLine, body)
+	}
+	if !strings.Contains(body, `le="+Inf"} 3`) {
+		t.Errorf("body missing +Inf bucket line, got:\n%s", body)
+	}
+	if !strings.Contains(body, `bench_test_count{model="x"} 3`) {
+		t.Errorf("body missing _count line, got:\n%s", body)
+	}
+	wantSum := 2*uint64(histBucketUpperNS(1)) + 1*uint64(histBucketUpperNS(2))
+	wantSumLine := fmt.Sprintf(`bench_test_sum{model="x"} %s`, secondsStr(int64(wantSum)))
+	if !strings.Contains(body, wantSumLine) {
+		t.Errorf("body missing _sum line %q, got:\n%s", wantSumLine, body)
+	}
+}
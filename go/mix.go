@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// OpType is one kind of operation a worker can perform against a
+// model. The zero value, OpGet, is also what every model defaults to
+// when no --mix is configured.
+type OpType int
+
+const (
+	OpGet OpType = iota
+	OpPut
+	OpDelete
+	OpScan
+	OpGetOrCompute
+	numOpTypes
+)
+
+var opTypeNames = [numOpTypes]string{"get", "put", "delete", "scan", "getOrCompute"}
+
+func (o OpType) String() string { return opTypeNames[o] }
+
+// OpMix is a weighted selection over OpTypes, parsed from a flag like
+// "get=80,put=15,delete=4,scan=1". Once built it's immutable and safe
+// to share across goroutines.
+type OpMix struct {
+	spec    string
+	ops     []OpType
+	weights []int
+	total   int
+}
+
+func parseMix(spec string) (*OpMix, error) {
+	mix := &OpMix{spec: spec}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("mix: bad term %q, want name=weight", part)
+		}
+		var op OpType
+		found := false
+		for i, name := range opTypeNames {
+			if name == kv[0] {
+				op = OpType(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("mix: unknown op %q", kv[0])
+		}
+		w, err := strconv.Atoi(kv[1])
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("mix: bad weight for %q: %v", kv[0], kv[1])
+		}
+		mix.ops = append(mix.ops, op)
+		mix.weights = append(mix.weights, w)
+		mix.total += w
+	}
+	if mix.total == 0 {
+		return nil, fmt.Errorf("mix: no weighted ops in %q", spec)
+	}
+	return mix, nil
+}
+
+// pick draws an OpType from the mix using r.
+func (m *OpMix) pick(r *rand.Rand) OpType {
+	x := r.IntN(m.total)
+	cum := 0
+	for i, w := range m.weights {
+		cum += w
+		if x < cum {
+			return m.ops[i]
+		}
+	}
+	return m.ops[len(m.ops)-1]
+}
+
+// opCounters is a per-goroutine, lock-free set of per-OpType counters,
+// merged the same way as localHist once all goroutines finish.
+type opCounters [numOpTypes]uint64
+
+func mergeOpCounters(locals []*opCounters) opCounters {
+	var total opCounters
+	for _, l := range locals {
+		for op := range total {
+			total[op] += l[op]
+		}
+	}
+	return total
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestParseMix(t *testing.T) {
+	cases := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{"valid spec", "get=80,put=15,delete=4,scan=1", false},
+		{"unknown op", "get=80,frobnicate=20", true},
+		{"malformed term", "get=80,put", true},
+		{"zero total", "get=0,put=0", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mix, err := parseMix(c.spec)
+			if c.wantErr {
+				if err == nil {
+					t.Fatalf("parseMix(%q) = %v, want error", c.spec, mix)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseMix(%q) returned error: %v", c.spec, err)
+			}
+			if mix.total != 100 {
+				t.Errorf("total = %d, want 100", mix.total)
+			}
+			if len(mix.ops) != 4 {
+				t.Errorf("len(ops) = %d, want 4", len(mix.ops))
+			}
+		})
+	}
+}
+
+func TestOpMixPick(t *testing.T) {
+	mix, err := parseMix("get=100")
+	if err != nil {
+		t.Fatalf("parseMix: %v", err)
+	}
+	r := rand.New(rand.NewPCG(1, 1))
+	for i := 0; i < 100; i++ {
+		if op := mix.pick(r); op != OpGet {
+			t.Fatalf("pick() = %v, want OpGet", op)
+		}
+	}
+}
+
+func TestOpMixPickDistribution(t *testing.T) {
+	mix, err := parseMix("get=1,put=0,delete=0,scan=0,getOrCompute=0")
+	if err != nil {
+		t.Fatalf("parseMix: %v", err)
+	}
+	r := rand.New(rand.NewPCG(1, 1))
+	for i := 0; i < 100; i++ {
+		if op := mix.pick(r); op != OpGet {
+			t.Fatalf("pick() with a single weighted op = %v, want OpGet", op)
+		}
+	}
+}
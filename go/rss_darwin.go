@@ -0,0 +1,27 @@
+//go:build darwin && cgo
+
+package main
+
+/*
+#include <mach/mach.h>
+*/
+import "C"
+import "unsafe"
+
+// rss asks the Mach kernel for the task's resident size directly,
+// mirroring what Activity Monitor reports, since Go's runtime.MemStats
+// has no RSS equivalent on Darwin.
+func rss() uint64 {
+	var info C.mach_task_basic_info_data_t
+	count := C.mach_msg_type_number_t(C.MACH_TASK_BASIC_INFO_COUNT)
+	kr := C.task_info(
+		C.mach_task_self_,
+		C.MACH_TASK_BASIC_INFO,
+		C.task_info_t(unsafe.Pointer(&info)),
+		&count,
+	)
+	if kr != C.KERN_SUCCESS {
+		return 0
+	}
+	return uint64(info.resident_size)
+}
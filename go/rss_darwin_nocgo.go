@@ -0,0 +1,15 @@
+//go:build darwin && !cgo
+
+package main
+
+import "runtime"
+
+// rss falls back to Sys (address space reserved from the OS) when
+// cross-compiling to darwin with CGO_ENABLED=0, since the real
+// task_info-based sampler in rss_darwin.go needs cgo. It's an
+// overestimate, but keeps a cgo-less darwin build working at all.
+func rss() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.Sys
+}
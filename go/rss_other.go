@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "runtime"
+
+// rss falls back to Sys (address space reserved from the OS) on
+// platforms without a dedicated RSS sampler; it's an overestimate but
+// keeps the tool running everywhere.
+func rss() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.Sys
+}
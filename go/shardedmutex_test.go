@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestNewShardedMutexForInitializesEveryStripe(t *testing.T) {
+	sm := NewShardedMutexFor[int, int64](4, func(k int) uint64 { return uint64(k) })
+	if got, want := len(sm.stripes), 4; got != want {
+		t.Fatalf("len(stripes) = %d, want %d", got, want)
+	}
+	for i, st := range sm.stripes {
+		if st.m == nil {
+			t.Errorf("stripe %d: m is nil, want an initialized map", i)
+		}
+	}
+}
+
+func TestNewShardedMutexForClampsStripeCount(t *testing.T) {
+	sm := NewShardedMutexFor[int, int64](0, func(k int) uint64 { return uint64(k) })
+	if got, want := len(sm.stripes), 1; got != want {
+		t.Fatalf("len(stripes) = %d, want %d", got, want)
+	}
+}
+
+func TestShardedMutexForStripeFor(t *testing.T) {
+	sm := NewShardedMutexFor[int, int64](8, func(k int) uint64 { return uint64(k) })
+	cases := []struct {
+		key  int
+		want int
+	}{
+		{0, 0},
+		{7, 7},
+		{8, 0},
+		{15, 7},
+	}
+	for _, c := range cases {
+		if got := sm.stripeFor(c.key); got != c.want {
+			t.Errorf("stripeFor(%d) = %d, want %d", c.key, got, c.want)
+		}
+	}
+}
+
+func TestShardedMutexForLockGivesWritableStripe(t *testing.T) {
+	sm := NewShardedMutexFor[int, int64](4, func(k int) uint64 { return uint64(k) })
+	st, unlock := sm.Lock(2)
+	st.m[2] = 42
+	unlock()
+
+	st2, unlock2 := sm.Lock(2)
+	defer unlock2()
+	if got, want := st2.m[2], int64(42); got != want {
+		t.Errorf("m[2] = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"runtime"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v3"
+)
+
+// runXsync drives the same read/increment workload against xsync's
+// lock-free MapOf, using Compute for the write path instead of a
+// Load+CompareAndSwap retry loop.
+func runXsync(ctx context.Context, threads, iterations, keys int, readRatio float64, seed uint64, kg KeyGen) {
+	m := xsync.NewMapOf[int, int64]()
+	// prefill
+	for i := 0; i < keys; i++ {
+		m.Store(i, 0)
+	}
+	runWork(ctx, "go"+runtime.Version(), "threads-xsync.MapOf", threads, iterations, keys, readRatio, seed, 0, "xsync.MapOf[int,int64]", "", func(ctx context.Context, tid int, n int, h *localHist, oc *opCounters) {
+		r := rand.New(rand.NewPCG(seed+uint64(tid), seed*1315423911+uint64(tid)))
+		reads := int(readRatio * 1000.0)
+		for i := 0; n < 0 || i < n; i++ {
+			if n < 0 && i&1023 == 0 && ctx.Err() != nil {
+				break
+			}
+			k := kg.Next(r)
+			op := OpGet
+			if opMix != nil {
+				op = opMix.pick(r)
+			} else if int(r.IntN(1000)) >= reads {
+				op = OpPut
+			}
+			opStart := time.Now()
+			switch op {
+			case OpGet:
+				m.Load(k)
+			case OpPut:
+				m.Compute(k, func(old int64, loaded bool) (int64, bool) {
+					if !loaded {
+						return 1, false
+					}
+					return old + 1, false
+				})
+			case OpDelete:
+				m.Delete(k)
+			case OpScan:
+				visited := 0
+				m.Range(func(key int, value int64) bool {
+					visited++
+					return visited < scanLimit
+				})
+			case OpGetOrCompute:
+				m.LoadOrStore(k, 0)
+			}
+			recordOp(h, oc, op, time.Since(opStart).Nanoseconds())
+		}
+	}, m)
+}
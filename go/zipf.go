@@ -0,0 +1,156 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// KeyGen produces the next key to operate on for one iteration. All
+// implementations must be safe for concurrent use by multiple readers
+// across goroutines, since any precomputed state (e.g. the Zipfian
+// zeta term) is built once at startup and shared read-only.
+type KeyGen interface {
+	Next(r *rand.Rand) int
+}
+
+func newKeyGen(distribution string, keys int, zipfS, hotOpsPct, hotKeysPct float64) KeyGen {
+	switch distribution {
+	case "zipf":
+		return newZipfKeyGen(keys, zipfS)
+	case "latest":
+		return newLatestKeyGen(keys, zipfS)
+	case "hotset":
+		return newHotsetKeyGen(keys, hotOpsPct, hotKeysPct)
+	default:
+		return uniformKeyGen{keys: keys}
+	}
+}
+
+type uniformKeyGen struct{ keys int }
+
+func (u uniformKeyGen) Next(r *rand.Rand) int { return int(r.IntN(u.keys)) }
+
+// zipfGenerator is a port of YCSB's ZipfianGenerator: it draws a rank
+// in [0, itemCount) from a Zipfian distribution with skew `theta`. The
+// zeta(n, theta) term is the only part that's expensive to compute, so
+// it's precomputed once in newZipfGenerator and the generator itself is
+// immutable and safe to share across goroutines.
+type zipfGenerator struct {
+	itemCount           uint64
+	theta, alpha, zetan float64
+	eta                 float64
+}
+
+func zeta(n uint64, theta float64) float64 {
+	var sum float64
+	for i := uint64(1); i <= n; i++ {
+		sum += 1.0 / math.Pow(float64(i), theta)
+	}
+	return sum
+}
+
+func newZipfGenerator(itemCount uint64, theta float64) *zipfGenerator {
+	zeta2 := zeta(2, theta)
+	zetan := zeta(itemCount, theta)
+	alpha := 1.0 / (1.0 - theta)
+	eta := (1 - math.Pow(2.0/float64(itemCount), 1-theta)) / (1 - zeta2/zetan)
+	return &zipfGenerator{itemCount: itemCount, theta: theta, alpha: alpha, zetan: zetan, eta: eta}
+}
+
+// next draws a rank in [0, itemCount) given a uniform u in [0, 1).
+func (z *zipfGenerator) next(u float64) uint64 {
+	uz := u * z.zetan
+	if uz < 1.0 {
+		return 0
+	}
+	if uz < 1.0+math.Pow(0.5, z.theta) {
+		return 1
+	}
+	return uint64(float64(z.itemCount) * math.Pow(z.eta*u-z.eta+1, z.alpha))
+}
+
+// zipfKeyGen is the "scrambled" variant: the raw Zipfian rank is
+// hashed across the keyspace so the hot keys aren't simply the lowest
+// indices, matching YCSB's ScrambledZipfianGenerator.
+type zipfKeyGen struct {
+	gen  *zipfGenerator
+	keys uint64
+}
+
+func newZipfKeyGen(keys int, theta float64) *zipfKeyGen {
+	return &zipfKeyGen{gen: newZipfGenerator(uint64(keys), theta), keys: uint64(keys)}
+}
+
+func (z *zipfKeyGen) Next(r *rand.Rand) int {
+	rank := z.gen.next(r.Float64())
+	return int(fnvHash64(rank) % z.keys)
+}
+
+// latestKeyGen skews toward the highest key indices instead of
+// scrambling across the whole keyspace, modeling YCSB's
+// LatestGenerator where the hot keys are the most recently inserted
+// ones rather than a fixed scrambled set.
+type latestKeyGen struct {
+	gen  *zipfGenerator
+	keys uint64
+}
+
+func newLatestKeyGen(keys int, theta float64) *latestKeyGen {
+	return &latestKeyGen{gen: newZipfGenerator(uint64(keys), theta), keys: uint64(keys)}
+}
+
+func (z *latestKeyGen) Next(r *rand.Rand) int {
+	rank := z.gen.next(r.Float64())
+	if rank >= z.keys {
+		rank = z.keys - 1
+	}
+	return int(z.keys - 1 - rank)
+}
+
+// hotsetKeyGen sends hotOpsPct of requests to the first hotKeysPct of
+// the keyspace and the rest uniformly across the remainder.
+type hotsetKeyGen struct {
+	hotOpsPct float64
+	hotCount  int
+	keys      int
+}
+
+func newHotsetKeyGen(keys int, hotOpsPct, hotKeysPct float64) *hotsetKeyGen {
+	hotCount := int(float64(keys) * hotKeysPct)
+	if hotCount < 1 {
+		hotCount = 1
+	}
+	if hotCount > keys {
+		hotCount = keys
+	}
+	return &hotsetKeyGen{hotOpsPct: hotOpsPct, hotCount: hotCount, keys: keys}
+}
+
+func (h *hotsetKeyGen) Next(r *rand.Rand) int {
+	if r.Float64() < h.hotOpsPct {
+		return int(r.IntN(h.hotCount))
+	}
+	rest := h.keys - h.hotCount
+	if rest <= 0 {
+		return int(r.IntN(h.keys))
+	}
+	return h.hotCount + int(r.IntN(rest))
+}
+
+// fnvHash64 is YCSB's 64-bit FNV hash used to scramble a Zipfian rank
+// across a keyspace: FNV-1a (xor each byte in, then multiply) over the
+// value's eight bytes, lowest byte first.
+func fnvHash64(val uint64) uint64 {
+	const (
+		fnvOffsetBasis64 = 0xCBF29CE484222325
+		fnvPrime64       = 1099511628211
+	)
+	hash := uint64(fnvOffsetBasis64)
+	for i := 0; i < 8; i++ {
+		octet := val & 0xff
+		val >>= 8
+		hash ^= octet
+		hash *= fnvPrime64
+	}
+	return hash
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestZipfKeyGenIsSkewed(t *testing.T) {
+	const keys = 1000
+	const samples = 200_000
+	zk := newZipfKeyGen(keys, 0.99)
+	r := rand.New(rand.NewPCG(1, 1))
+
+	counts := make([]int, keys)
+	for i := 0; i < samples; i++ {
+		k := zk.Next(r)
+		if k < 0 || k >= keys {
+			t.Fatalf("Next returned out-of-range key %d", k)
+		}
+		counts[k]++
+	}
+
+	mean := float64(samples) / float64(keys)
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+	// A uniform distribution would put every key within a few stddevs
+	// of the mean; the default zipf-s=0.99 should make the hottest key
+	// land at many multiples of it.
+	if float64(max) < mean*10 {
+		t.Fatalf("zipf distribution looks uniform: max count %d, mean %.1f", max, mean)
+	}
+}
+
+func TestLatestKeyGenSkewsHighIndices(t *testing.T) {
+	const keys = 1000
+	const samples = 200_000
+	lk := newLatestKeyGen(keys, 0.99)
+	r := rand.New(rand.NewPCG(1, 1))
+
+	var highHalf, lowHalf int
+	for i := 0; i < samples; i++ {
+		k := lk.Next(r)
+		if k < 0 || k >= keys {
+			t.Fatalf("Next returned out-of-range key %d", k)
+		}
+		if k >= keys/2 {
+			highHalf++
+		} else {
+			lowHalf++
+		}
+	}
+	if highHalf <= lowHalf {
+		t.Fatalf("latest distribution didn't skew toward high indices: high=%d low=%d", highHalf, lowHalf)
+	}
+}
+
+func TestHotsetKeyGenHonorsFractions(t *testing.T) {
+	const keys = 1000
+	const samples = 100_000
+	const hotOps = 0.9
+	const hotKeys = 0.1
+	hk := newHotsetKeyGen(keys, hotOps, hotKeys)
+	r := rand.New(rand.NewPCG(1, 1))
+
+	hotCount := int(float64(keys) * hotKeys)
+	var hits int
+	for i := 0; i < samples; i++ {
+		k := hk.Next(r)
+		if k < 0 || k >= keys {
+			t.Fatalf("Next returned out-of-range key %d", k)
+		}
+		if k < hotCount {
+			hits++
+		}
+	}
+	gotFrac := float64(hits) / float64(samples)
+	if diff := gotFrac - hotOps; diff < -0.02 || diff > 0.02 {
+		t.Fatalf("hot fraction = %.3f, want ~%.2f", gotFrac, hotOps)
+	}
+}
+
+func TestZeta(t *testing.T) {
+	// zeta(n, 1) is the harmonic series H_n; zeta(1, theta) is always 1
+	// regardless of theta.
+	if got := zeta(1, 0.99); got != 1.0 {
+		t.Errorf("zeta(1, 0.99) = %v, want 1.0", got)
+	}
+	const want = 1.0 + 0.5 + 1.0/3.0
+	if got := zeta(3, 1.0); math.Abs(got-want) > 1e-9 {
+		t.Errorf("zeta(3, 1.0) = %v, want %v", got, want)
+	}
+}